@@ -0,0 +1,41 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/pingcap/pd/server/schedule"
+)
+
+// runScheduler is the dispatcher tick loop for a single scheduler: every
+// interval it calls schedule.Dispatch and hands whatever operators come
+// back to apply. Dispatch prefers a scheduler's ScheduleBatch over its
+// single-op Schedule when the scheduler implements schedule.BatchScheduler
+// (e.g. balance-region), so one tick can submit several non-conflicting
+// operators instead of always waiting for the next tick to submit more.
+func runScheduler(s schedule.Dispatchable, cluster schedule.Cluster, interval time.Duration, apply func(*schedule.Operator), stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, op := range schedule.Dispatch(s, cluster) {
+				apply(op)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
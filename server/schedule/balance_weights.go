@@ -0,0 +1,45 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+// BalanceRegionWeights configures how much each store dimension
+// contributes to the composite load score used to rank stores for
+// balance-region scheduling, and how much a single dimension is allowed
+// to regress, relative to the cluster average, when a move improves the
+// others.
+type BalanceRegionWeights struct {
+	RegionCountWeight float64 `toml:"region-count-weight" json:"region-count-weight"`
+	StorageWeight     float64 `toml:"storage-weight" json:"storage-weight"`
+	WriteBytesWeight  float64 `toml:"write-bytes-weight" json:"write-bytes-weight"`
+	ReadBytesWeight   float64 `toml:"read-bytes-weight" json:"read-bytes-weight"`
+	PendingPeerWeight float64 `toml:"pending-peer-weight" json:"pending-peer-weight"`
+	ToleranceRatio    float64 `toml:"tolerance-ratio" json:"tolerance-ratio"`
+}
+
+// DefaultBalanceRegionWeights are used whenever an operator has not
+// configured per-dimension weights. They weight storage usage and
+// pending peer count as heavily as region count, and write/read byte
+// rate at half that — this is a deliberate behavior change from the old
+// region-count-only balancer: clusters that upgrade without touching
+// config will now also move regions to fix storage or pending-peer
+// skew even when region counts are already balanced. A 5% tolerance
+// applies to every dimension regardless of its weight.
+var DefaultBalanceRegionWeights = BalanceRegionWeights{
+	RegionCountWeight: 1,
+	StorageWeight:     1,
+	WriteBytesWeight:  0.5,
+	ReadBytesWeight:   0.5,
+	PendingPeerWeight: 1,
+	ToleranceRatio:    0.05,
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+// BatchScheduler is implemented by schedulers that can produce several
+// non-conflicting operators in a single tick instead of just one.
+// Dispatch prefers ScheduleBatch over Schedule whenever a Scheduler
+// happens to implement it.
+type BatchScheduler interface {
+	Scheduler
+	// ScheduleBatch returns up to the scheduler's own limit of operators
+	// that can all be applied without conflicting with one another.
+	ScheduleBatch(cluster Cluster) []*Operator
+}
+
+// Dispatchable is the minimal surface Dispatch needs from a scheduler.
+// Scheduler (and so BatchScheduler) always satisfies it.
+type Dispatchable interface {
+	IsScheduleAllowed() bool
+	Schedule(cluster Cluster) *Operator
+}
+
+// Dispatch runs one scheduling pass for s against cluster and returns the
+// operators to submit. When s also implements BatchScheduler, Dispatch
+// consumes ScheduleBatch's slice instead of Schedule's single operator,
+// re-checking IsScheduleAllowed before accepting each operator so a
+// scheduler that becomes throttled partway through a batch (e.g. by
+// another scheduler's operators filling the shared limiter) stops being
+// handed more work from the same batch.
+func Dispatch(s Dispatchable, cluster Cluster) []*Operator {
+	batcher, ok := s.(interface {
+		ScheduleBatch(cluster Cluster) []*Operator
+	})
+	if !ok {
+		if !s.IsScheduleAllowed() {
+			return nil
+		}
+		op := s.Schedule(cluster)
+		if op == nil {
+			return nil
+		}
+		return []*Operator{op}
+	}
+
+	var accepted []*Operator
+	for _, op := range batcher.ScheduleBatch(cluster) {
+		if !s.IsScheduleAllowed() {
+			break
+		}
+		accepted = append(accepted, op)
+	}
+	return accepted
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import "testing"
+
+// fakeScheduler is a minimal Dispatchable used to exercise Dispatch
+// without needing a real Cluster or Options.
+type fakeScheduler struct {
+	allowedCalls int // number of leading IsScheduleAllowed calls that return true
+	calls        int
+	single       *Operator
+}
+
+func (f *fakeScheduler) IsScheduleAllowed() bool {
+	f.calls++
+	return f.calls <= f.allowedCalls
+}
+
+func (f *fakeScheduler) Schedule(cluster Cluster) *Operator {
+	return f.single
+}
+
+type fakeBatchScheduler struct {
+	fakeScheduler
+	batch []*Operator
+}
+
+func (f *fakeBatchScheduler) ScheduleBatch(cluster Cluster) []*Operator {
+	return f.batch
+}
+
+func TestDispatchSingleOpScheduler(t *testing.T) {
+	var op Operator
+	s := &fakeScheduler{allowedCalls: 1, single: &op}
+	ops := Dispatch(s, nil)
+	if len(ops) != 1 || ops[0] != &op {
+		t.Fatalf("expected the single operator to be returned, got %v", ops)
+	}
+}
+
+func TestDispatchSingleOpSchedulerNotAllowed(t *testing.T) {
+	var op Operator
+	s := &fakeScheduler{allowedCalls: 0, single: &op}
+	if ops := Dispatch(s, nil); ops != nil {
+		t.Fatalf("expected no operators when IsScheduleAllowed is false, got %v", ops)
+	}
+}
+
+func TestDispatchSingleOpSchedulerNilOperator(t *testing.T) {
+	s := &fakeScheduler{allowedCalls: 1}
+	if ops := Dispatch(s, nil); ops != nil {
+		t.Fatalf("expected no operators when Schedule returns nil, got %v", ops)
+	}
+}
+
+func TestDispatchBatchSchedulerConsumesWholeBatch(t *testing.T) {
+	var a, b, c Operator
+	batch := []*Operator{&a, &b, &c}
+	s := &fakeBatchScheduler{fakeScheduler: fakeScheduler{allowedCalls: len(batch)}, batch: batch}
+	ops := Dispatch(s, nil)
+	if len(ops) != len(batch) {
+		t.Fatalf("expected all %d batched operators to be accepted, got %d", len(batch), len(ops))
+	}
+}
+
+func TestDispatchBatchSchedulerStopsWhenThrottledMidBatch(t *testing.T) {
+	var a, b, c Operator
+	batch := []*Operator{&a, &b, &c}
+	s := &fakeBatchScheduler{fakeScheduler: fakeScheduler{allowedCalls: 2}, batch: batch}
+	ops := Dispatch(s, nil)
+	if len(ops) != 2 {
+		t.Fatalf("expected Dispatch to stop accepting once IsScheduleAllowed turns false, got %d operators", len(ops))
+	}
+}
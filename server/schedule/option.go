@@ -0,0 +1,69 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+// Options exposes the subset of the cluster's scheduling configuration
+// that schedulers in this package read at runtime.
+type Options interface {
+	GetMaxReplicas() int
+	GetLocationLabels() []string
+	GetRegionScheduleLimit() uint64
+	// GetBalanceRegionWeights returns the per-dimension weights and
+	// tolerance used by the balance-region scheduler's composite store
+	// score. Never returns nil.
+	GetBalanceRegionWeights() *BalanceRegionWeights
+}
+
+// ScheduleConfig is the serializable configuration backing the default
+// Options implementation.
+type ScheduleConfig struct {
+	MaxReplicas          int                  `toml:"max-replicas" json:"max-replicas"`
+	LocationLabels       []string             `toml:"location-labels" json:"location-labels"`
+	RegionScheduleLimit  uint64               `toml:"region-schedule-limit" json:"region-schedule-limit"`
+	BalanceRegionWeights BalanceRegionWeights `toml:"balance-region-weights" json:"balance-region-weights"`
+}
+
+// ScheduleOptions is the config-file-backed implementation of Options.
+type ScheduleOptions struct {
+	cfg *ScheduleConfig
+}
+
+// NewScheduleOptions wraps cfg as Options, falling back to
+// DefaultBalanceRegionWeights when cfg didn't configure any.
+func NewScheduleOptions(cfg *ScheduleConfig) *ScheduleOptions {
+	if cfg.BalanceRegionWeights == (BalanceRegionWeights{}) {
+		cfg.BalanceRegionWeights = DefaultBalanceRegionWeights
+	}
+	return &ScheduleOptions{cfg: cfg}
+}
+
+// GetMaxReplicas implements Options.
+func (o *ScheduleOptions) GetMaxReplicas() int {
+	return o.cfg.MaxReplicas
+}
+
+// GetLocationLabels implements Options.
+func (o *ScheduleOptions) GetLocationLabels() []string {
+	return o.cfg.LocationLabels
+}
+
+// GetRegionScheduleLimit implements Options.
+func (o *ScheduleOptions) GetRegionScheduleLimit() uint64 {
+	return o.cfg.RegionScheduleLimit
+}
+
+// GetBalanceRegionWeights implements Options.
+func (o *ScheduleOptions) GetBalanceRegionWeights() *BalanceRegionWeights {
+	return &o.cfg.BalanceRegionWeights
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"math/rand"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// RegionPickPolicy picks one region out of a store's region list to move
+// off that store. Implementations are free to use cluster state (such as
+// hot region stats) to make their choice.
+type RegionPickPolicy interface {
+	// Pick returns one of regions, or nil if regions is empty.
+	Pick(cluster Cluster, storeID uint64, regions []*core.RegionInfo) *core.RegionInfo
+}
+
+// NewRegionPickPolicy resolves a policy by name. It returns the largest
+// region policy, the historical default, when name is empty.
+func NewRegionPickPolicy(name string) (RegionPickPolicy, error) {
+	switch name {
+	case "", "largest":
+		return LargestRegionPolicy{}, nil
+	case "smallest":
+		return SmallestRegionPolicy{}, nil
+	case "coldest":
+		return ColdestRegionPolicy{}, nil
+	case "random":
+		return NewReservoirSampledPolicy(), nil
+	default:
+		return nil, errUnknownRegionPickPolicy(name)
+	}
+}
+
+type regionPickPolicyError struct {
+	name string
+}
+
+func (e *regionPickPolicyError) Error() string {
+	return "unknown region pick policy: " + e.name
+}
+
+func errUnknownRegionPickPolicy(name string) error {
+	return &regionPickPolicyError{name: name}
+}
+
+// LargestRegionPolicy picks the region with the largest approximate size,
+// the original balance-region behavior of preferring to move the biggest
+// region off the busiest store.
+type LargestRegionPolicy struct{}
+
+// Pick implements RegionPickPolicy.
+func (LargestRegionPolicy) Pick(cluster Cluster, storeID uint64, regions []*core.RegionInfo) *core.RegionInfo {
+	var best *core.RegionInfo
+	for _, region := range regions {
+		if best == nil || region.GetApproximateSize() > best.GetApproximateSize() {
+			best = region
+		}
+	}
+	return best
+}
+
+// SmallestRegionPolicy picks the region with the smallest approximate
+// size, which moves faster and is useful when the goal is to relieve a
+// store's region count quickly rather than its data volume.
+type SmallestRegionPolicy struct{}
+
+// Pick implements RegionPickPolicy.
+func (SmallestRegionPolicy) Pick(cluster Cluster, storeID uint64, regions []*core.RegionInfo) *core.RegionInfo {
+	var best *core.RegionInfo
+	for _, region := range regions {
+		if best == nil || region.GetApproximateSize() < best.GetApproximateSize() {
+			best = region
+		}
+	}
+	return best
+}
+
+// ColdestRegionPolicy picks the first region that isn't currently hot, so
+// that moves never disturb regions the hot-region scheduler is already
+// balancing.
+type ColdestRegionPolicy struct{}
+
+// Pick implements RegionPickPolicy.
+func (ColdestRegionPolicy) Pick(cluster Cluster, storeID uint64, regions []*core.RegionInfo) *core.RegionInfo {
+	for _, region := range regions {
+		if !cluster.IsRegionHot(region.GetId()) {
+			return region
+		}
+	}
+	return nil
+}
+
+// ReservoirSampledPolicy picks uniformly at random among a store's
+// regions using Vitter's algorithm R, so a fair choice takes one pass
+// over the region list and never sorts it. Pick still takes that list
+// as a fully built slice.
+type ReservoirSampledPolicy struct {
+	rnd *rand.Rand
+}
+
+// NewReservoirSampledPolicy creates a ReservoirSampledPolicy.
+func NewReservoirSampledPolicy() *ReservoirSampledPolicy {
+	return &ReservoirSampledPolicy{rnd: rand.New(rand.NewSource(rand.Int63()))}
+}
+
+// Pick implements RegionPickPolicy using reservoir sampling with a
+// reservoir of size one: the region at index i (counting from zero)
+// replaces the current pick with probability 1/(i+1), giving every
+// region an equal 1/n chance overall.
+func (p *ReservoirSampledPolicy) Pick(cluster Cluster, storeID uint64, regions []*core.RegionInfo) *core.RegionInfo {
+	var chosen *core.RegionInfo
+	for i, region := range regions {
+		if i == 0 || p.rnd.Intn(i+1) == 0 {
+			chosen = region
+		}
+	}
+	return chosen
+}
@@ -0,0 +1,158 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+)
+
+func newTestRegion(id uint64, approximateSize int64) *core.RegionInfo {
+	meta := &metapb.Region{Id: id}
+	return core.NewRegionInfo(meta, nil, core.SetApproximateSize(approximateSize))
+}
+
+// fakeHotCluster is a minimal Cluster stub used to control IsRegionHot
+// in ColdestRegionPolicy tests; every other Cluster method panics if
+// called, which is fine since Pick never touches them.
+type fakeHotCluster struct {
+	Cluster
+	hot map[uint64]bool
+}
+
+func (f *fakeHotCluster) IsRegionHot(id uint64) bool {
+	return f.hot[id]
+}
+
+func TestLargestRegionPolicyPicksBiggest(t *testing.T) {
+	regions := []*core.RegionInfo{
+		newTestRegion(1, 10),
+		newTestRegion(2, 50),
+		newTestRegion(3, 30),
+	}
+	picked := LargestRegionPolicy{}.Pick(nil, 1, regions)
+	if picked == nil || picked.GetId() != 2 {
+		t.Fatalf("expected region 2 (largest), got %v", picked)
+	}
+}
+
+func TestSmallestRegionPolicyPicksSmallest(t *testing.T) {
+	regions := []*core.RegionInfo{
+		newTestRegion(1, 10),
+		newTestRegion(2, 50),
+		newTestRegion(3, 30),
+	}
+	picked := SmallestRegionPolicy{}.Pick(nil, 1, regions)
+	if picked == nil || picked.GetId() != 1 {
+		t.Fatalf("expected region 1 (smallest), got %v", picked)
+	}
+}
+
+func TestRegionPickPoliciesOnEmptyStore(t *testing.T) {
+	var regions []*core.RegionInfo
+	if got := (LargestRegionPolicy{}).Pick(nil, 1, regions); got != nil {
+		t.Fatalf("expected nil on empty region list, got %v", got)
+	}
+	if got := (SmallestRegionPolicy{}).Pick(nil, 1, regions); got != nil {
+		t.Fatalf("expected nil on empty region list, got %v", got)
+	}
+	if got := NewReservoirSampledPolicy().Pick(nil, 1, regions); got != nil {
+		t.Fatalf("expected nil on empty region list, got %v", got)
+	}
+}
+
+func TestRegionPickPoliciesOnSingleRegionStore(t *testing.T) {
+	regions := []*core.RegionInfo{newTestRegion(7, 10)}
+	for name, policy := range map[string]RegionPickPolicy{
+		"largest":   LargestRegionPolicy{},
+		"smallest":  SmallestRegionPolicy{},
+		"reservoir": NewReservoirSampledPolicy(),
+	} {
+		if got := policy.Pick(nil, 1, regions); got == nil || got.GetId() != 7 {
+			t.Fatalf("%s policy: expected the only region (7), got %v", name, got)
+		}
+	}
+}
+
+func TestReservoirSampledPolicyCoversAllRegions(t *testing.T) {
+	regions := []*core.RegionInfo{
+		newTestRegion(1, 10),
+		newTestRegion(2, 20),
+		newTestRegion(3, 30),
+		newTestRegion(4, 40),
+	}
+	policy := NewReservoirSampledPolicy()
+	seen := make(map[uint64]bool)
+	for i := 0; i < 500; i++ {
+		picked := policy.Pick(nil, 1, regions)
+		if picked == nil {
+			t.Fatalf("expected a region to be picked, got nil")
+		}
+		seen[picked.GetId()] = true
+	}
+	if len(seen) != len(regions) {
+		t.Fatalf("expected reservoir sampling to eventually cover all %d regions, saw %d", len(regions), len(seen))
+	}
+}
+
+func TestColdestRegionPolicyAllHotReturnsNil(t *testing.T) {
+	regions := []*core.RegionInfo{newTestRegion(1, 10), newTestRegion(2, 20)}
+	cluster := &fakeHotCluster{hot: map[uint64]bool{1: true, 2: true}}
+	if got := (ColdestRegionPolicy{}).Pick(cluster, 1, regions); got != nil {
+		t.Fatalf("expected nil when every region is hot, got %v", got)
+	}
+}
+
+func TestColdestRegionPolicySkipsHotRegions(t *testing.T) {
+	regions := []*core.RegionInfo{newTestRegion(1, 10), newTestRegion(2, 20), newTestRegion(3, 30)}
+	cluster := &fakeHotCluster{hot: map[uint64]bool{1: true, 2: true}}
+	got := (ColdestRegionPolicy{}).Pick(cluster, 1, regions)
+	if got == nil || got.GetId() != 3 {
+		t.Fatalf("expected the first non-hot region (3), got %v", got)
+	}
+}
+
+func TestColdestRegionPolicyNoneHotPicksFirst(t *testing.T) {
+	regions := []*core.RegionInfo{newTestRegion(7, 10), newTestRegion(8, 20)}
+	cluster := &fakeHotCluster{hot: map[uint64]bool{}}
+	got := (ColdestRegionPolicy{}).Pick(cluster, 1, regions)
+	if got == nil || got.GetId() != 7 {
+		t.Fatalf("expected the first region (7) when none are hot, got %v", got)
+	}
+}
+
+func TestColdestRegionPolicyOnEmptyStore(t *testing.T) {
+	cluster := &fakeHotCluster{hot: map[uint64]bool{}}
+	if got := (ColdestRegionPolicy{}).Pick(cluster, 1, nil); got != nil {
+		t.Fatalf("expected nil on empty region list, got %v", got)
+	}
+}
+
+func TestNewRegionPickPolicyUnknownName(t *testing.T) {
+	if _, err := NewRegionPickPolicy("does-not-exist"); err == nil {
+		t.Fatalf("expected an error for an unknown policy name")
+	}
+}
+
+func TestNewRegionPickPolicyDefault(t *testing.T) {
+	policy, err := NewRegionPickPolicy("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := policy.(LargestRegionPolicy); !ok {
+		t.Fatalf("expected the default policy to be LargestRegionPolicy, got %T", policy)
+	}
+}
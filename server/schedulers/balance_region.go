@@ -16,31 +16,150 @@ package schedulers
 import (
 	"time"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
 )
 
+// dimensionTolerance returns how much worse a target store's value is
+// allowed to be than the source store's, as the fraction ratio of the
+// cluster average avg, before the dimension is considered to have
+// regressed.
+func dimensionTolerance(avg, ratio float64) float64 {
+	return avg * ratio
+}
+
+// dimensionRegressed reports whether moving from a store scoring
+// sourceVal to one scoring targetVal on some dimension, whose cluster
+// average is avg, regresses that dimension beyond the allowed tolerance.
+// A dimension only counts as regressed if it ends up worse than both the
+// source store and the cluster average were.
+func dimensionRegressed(sourceVal, targetVal, avg, toleranceRatio float64) bool {
+	tolerance := dimensionTolerance(avg, toleranceRatio)
+	return targetVal > sourceVal+tolerance && targetVal > avg+tolerance
+}
+
+// compositeScore blends each dimension's value-to-cluster-average ratio
+// into a single load score using weights. A higher score means more
+// loaded. It's the pure-math core of storeLoadStats.score, split out so
+// the blending itself can be tested without constructing a *core.StoreInfo.
+func compositeScore(weights *schedule.BalanceRegionWeights, regionCountRatio, usedRatioRatio, writeRateRatio, readRateRatio, pendingPeerRatio float64) float64 {
+	return weights.RegionCountWeight*regionCountRatio +
+		weights.StorageWeight*usedRatioRatio +
+		weights.WriteBytesWeight*writeRateRatio +
+		weights.ReadBytesWeight*readRateRatio +
+		weights.PendingPeerWeight*pendingPeerRatio
+}
+
 func init() {
 	schedule.RegisterScheduler("balance-region", func(opt schedule.Options, limiter *schedule.Limiter, args []string) (schedule.Scheduler, error) {
-		return newBalanceRegionScheduler(opt, limiter), nil
+		policy, err := schedule.NewRegionPickPolicy(regionPickPolicyNameFromArgs(args))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return newBalanceRegionScheduler(opt, limiter, policy), nil
 	})
 }
 
+// regionPickPolicyNameFromArgs reads the region-pick-policy name an
+// operator configured for this scheduler, e.g. via
+// `scheduler add balance-region coldest`. Absent an explicit choice, it
+// returns "", which schedule.NewRegionPickPolicy resolves to the
+// historical largest-region default.
+func regionPickPolicyNameFromArgs(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
+
 const storeCacheInterval = 30 * time.Second
 
+var _ schedule.BatchScheduler = (*balanceRegionScheduler)(nil)
+
 type balanceRegionScheduler struct {
 	*baseScheduler
-	opt      schedule.Options
-	cache    *cache.TTLUint64
-	limit    uint64
-	selector schedule.Selector
+	opt     schedule.Options
+	cache   *cache.TTLUint64
+	limit   uint64
+	filters []schedule.Filter
+	policy  schedule.RegionPickPolicy
+}
+
+// storeLoadStats holds the cluster-wide averages used to normalize each
+// store's dimensions before they are combined into a composite score.
+type storeLoadStats struct {
+	avgRegionCount float64
+	avgUsedRatio   float64
+	avgWriteRate   float64
+	avgReadRate    float64
+	avgPendingPeer float64
+}
+
+// newStoreLoadStats computes the per-dimension cluster averages that
+// composite store scores are normalized against.
+func newStoreLoadStats(stores []*core.StoreInfo) *storeLoadStats {
+	stats := &storeLoadStats{}
+	if len(stores) == 0 {
+		return stats
+	}
+	var regionCount, usedRatio, writeRate, readRate, pendingPeer float64
+	for _, store := range stores {
+		regionCount += float64(store.RegionCount())
+		usedRatio += store.UsedRatio()
+		writeBytes, readBytes := store.GetBytesWriteRate(), store.GetBytesReadRate()
+		writeRate += writeBytes
+		readRate += readBytes
+		pendingPeer += float64(store.PendingPeerCount())
+	}
+	n := float64(len(stores))
+	stats.avgRegionCount = regionCount / n
+	stats.avgUsedRatio = usedRatio / n
+	stats.avgWriteRate = writeRate / n
+	stats.avgReadRate = readRate / n
+	stats.avgPendingPeer = pendingPeer / n
+	return stats
+}
+
+// score returns store's composite load score: the weighted sum of each
+// dimension normalized against the cluster average for that dimension.
+// A higher score means the store is more loaded.
+func (s *storeLoadStats) score(store *core.StoreInfo, weights *schedule.BalanceRegionWeights) float64 {
+	ratio := func(v, avg float64) float64 {
+		if avg == 0 {
+			return 0
+		}
+		return v / avg
+	}
+	writeBytes, readBytes := store.GetBytesWriteRate(), store.GetBytesReadRate()
+	return compositeScore(weights,
+		ratio(float64(store.RegionCount()), s.avgRegionCount),
+		ratio(store.UsedRatio(), s.avgUsedRatio),
+		ratio(writeBytes, s.avgWriteRate),
+		ratio(readBytes, s.avgReadRate),
+		ratio(float64(store.PendingPeerCount()), s.avgPendingPeer),
+	)
+}
+
+// scoreWithDelta is score, but with the store's region count adjusted by
+// regionCountDelta first. It lets ScheduleBatch see the projected score a
+// store would have after the moves already chosen earlier in the same
+// batch are applied, without having to mutate or re-copy the store.
+func (s *storeLoadStats) scoreWithDelta(store *core.StoreInfo, weights *schedule.BalanceRegionWeights, regionCountDelta int64) float64 {
+	base := s.score(store, weights)
+	if regionCountDelta == 0 || s.avgRegionCount == 0 {
+		return base
+	}
+	return base + weights.RegionCountWeight*float64(regionCountDelta)/s.avgRegionCount
 }
 
 // newBalanceRegionScheduler creates a scheduler that tends to keep regions on
-// each store balanced.
-func newBalanceRegionScheduler(opt schedule.Options, limiter *schedule.Limiter) schedule.Scheduler {
+// each store balanced. policy decides which region is picked off the
+// source store once it has been chosen; it defaults to LargestRegionPolicy
+// when nil.
+func newBalanceRegionScheduler(opt schedule.Options, limiter *schedule.Limiter, policy schedule.RegionPickPolicy) schedule.Scheduler {
 	ttlCache := cache.NewIDTTL(storeCacheInterval, 4*storeCacheInterval)
 	filters := []schedule.Filter{
 		schedule.NewCacheFilter(ttlCache),
@@ -49,13 +168,17 @@ func newBalanceRegionScheduler(opt schedule.Options, limiter *schedule.Limiter)
 		schedule.NewSnapshotCountFilter(opt),
 		schedule.NewStorageThresholdFilter(opt),
 	}
+	if policy == nil {
+		policy = schedule.LargestRegionPolicy{}
+	}
 	base := newBaseScheduler(limiter)
 	return &balanceRegionScheduler{
 		baseScheduler: base,
 		opt:           opt,
 		cache:         ttlCache,
 		limit:         1,
-		selector:      schedule.NewBalanceSelector(core.RegionKind, filters),
+		filters:       filters,
+		policy:        policy,
 	}
 }
 
@@ -74,8 +197,8 @@ func (s *balanceRegionScheduler) IsScheduleAllowed() bool {
 
 func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) *schedule.Operator {
 	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
-	// Select a peer from the store with most regions.
-	region, oldPeer := scheduleRemovePeer(cluster, s.GetName(), s.selector)
+	// Select a peer from the store with the highest composite load score.
+	region, oldPeer := s.selectRegionToMove(cluster)
 	if region == nil {
 		return nil
 	}
@@ -102,6 +225,175 @@ func (s *balanceRegionScheduler) Schedule(cluster schedule.Cluster) *schedule.Op
 	return op
 }
 
+// ScheduleBatch produces up to the scheduler's limit of move-peer
+// operators in a single pass, so large clusters don't have to wait one
+// dispatcher tick per move to converge. It tracks the region-count delta
+// each tentative move would apply to its source and target stores in a
+// scratch map, so later picks in the same batch rank stores by their
+// projected, not their currently-observed, load.
+func (s *balanceRegionScheduler) ScheduleBatch(cluster schedule.Cluster) []*schedule.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule_batch").Inc()
+	if minUint64(s.limit, s.opt.GetRegionScheduleLimit()) == 0 {
+		return nil
+	}
+
+	stores := cluster.GetStores()
+	stats := newStoreLoadStats(stores)
+	weights := s.opt.GetBalanceRegionWeights()
+
+	regionCountDelta := make(map[uint64]int64)
+	usedRegions := make(map[uint64]struct{})
+	exhaustedSources := make(map[uint64]struct{})
+
+	var ops []*schedule.Operator
+	// s.limit is re-read every iteration, not snapshotted once, because
+	// transferPeerTentative grows it via adjustBalanceLimit as operators
+	// are accepted — a batch should be able to use that growth within
+	// the same call instead of only after the next dispatcher tick.
+	for uint64(len(ops)) < minUint64(s.limit, s.opt.GetRegionScheduleLimit()) {
+		source := pickTentativeSource(stores, stats, weights, s.filters, regionCountDelta, exhaustedSources)
+		if source == nil {
+			break
+		}
+
+		region, oldPeer := s.pickUnusedRegion(cluster, source, usedRegions)
+		if region == nil {
+			// Nothing left to move off this store this batch; don't keep
+			// re-selecting it only to find the same result.
+			exhaustedSources[source.GetId()] = struct{}{}
+			continue
+		}
+		usedRegions[region.GetId()] = struct{}{}
+
+		if len(region.GetPeers()) != s.opt.GetMaxReplicas() {
+			schedulerCounter.WithLabelValues(s.GetName(), "abnormal_replica").Inc()
+			continue
+		}
+		if cluster.IsRegionHot(region.GetId()) {
+			schedulerCounter.WithLabelValues(s.GetName(), "region_hot").Inc()
+			continue
+		}
+
+		op, targetID, ok := s.transferPeerTentative(cluster, region, oldPeer, stats, weights, regionCountDelta)
+		if !ok {
+			s.cache.Put(oldPeer.GetStoreId())
+			continue
+		}
+
+		ops = append(ops, op)
+		regionCountDelta[oldPeer.GetStoreId()]--
+		regionCountDelta[targetID]++
+	}
+
+	schedulerCounter.WithLabelValues(s.GetName(), "new_operator_batch").Inc()
+	return ops
+}
+
+// filteredOut reports whether any of filters rejects store.
+func filteredOut(store *core.StoreInfo, filters []schedule.Filter) bool {
+	for _, f := range filters {
+		if f.Filter(store) {
+			return true
+		}
+	}
+	return false
+}
+
+// pickTentativeSource ranks stores by descending composite load score,
+// adjusted by each store's tentative regionCountDelta, skipping stores
+// that fail filters or are already known to have nothing left to offer
+// this batch. regionCountDelta and exhausted may be nil, in which case
+// every store is considered with no delta adjustment.
+func pickTentativeSource(stores []*core.StoreInfo, stats *storeLoadStats, weights *schedule.BalanceRegionWeights, filters []schedule.Filter, regionCountDelta map[uint64]int64, exhausted map[uint64]struct{}) *core.StoreInfo {
+	var best *core.StoreInfo
+	var bestScore float64
+	for _, store := range stores {
+		if _, skip := exhausted[store.GetId()]; skip {
+			continue
+		}
+		if filteredOut(store, filters) {
+			continue
+		}
+		score := stats.scoreWithDelta(store, weights, regionCountDelta[store.GetId()])
+		if best == nil || score > bestScore {
+			best, bestScore = store, score
+		}
+	}
+	return best
+}
+
+// pickUnusedRegion asks s.policy for a region to move off source, skipping
+// any region already claimed earlier in the same batch so two operators
+// never target the same region or its peer set.
+func (s *balanceRegionScheduler) pickUnusedRegion(cluster schedule.Cluster, source *core.StoreInfo, usedRegions map[uint64]struct{}) (*core.RegionInfo, *metapb.Peer) {
+	regions := cluster.GetStoreRegions(source.GetId())
+	candidates := make([]*core.RegionInfo, 0, len(regions))
+	for _, region := range regions {
+		if _, used := usedRegions[region.GetId()]; !used {
+			candidates = append(candidates, region)
+		}
+	}
+	region := s.policy.Pick(cluster, source.GetId(), candidates)
+	if region == nil {
+		return nil, nil
+	}
+	return region, region.GetStorePeer(source.GetId())
+}
+
+// transferPeerTentative is transferPeer's batch counterpart: it gates the
+// move on the tentative, delta-adjusted composite scores rather than the
+// cluster's currently-observed ones, and reports the target store so the
+// caller can fold the move into regionCountDelta.
+func (s *balanceRegionScheduler) transferPeerTentative(cluster schedule.Cluster, region *core.RegionInfo, oldPeer *metapb.Peer, stats *storeLoadStats, weights *schedule.BalanceRegionWeights, regionCountDelta map[uint64]int64) (op *schedule.Operator, targetStoreID uint64, ok bool) {
+	stores := cluster.GetRegionStores(region)
+	source := cluster.GetStore(oldPeer.GetStoreId())
+	scoreGuard := schedule.NewDistinctScoreFilter(s.opt.GetLocationLabels(), stores, source)
+
+	checker := schedule.NewReplicaChecker(s.opt, cluster, nil)
+	newPeer := checker.SelectBestPeerToAddReplica(region, scoreGuard)
+	if newPeer == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no_peer").Inc()
+		return nil, 0, false
+	}
+
+	target := cluster.GetStore(newPeer.GetStoreId())
+	if !s.shouldBalanceDimensions(stats, source, target, weights, regionCountDelta[source.GetId()], regionCountDelta[target.GetId()]) {
+		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+		return nil, 0, false
+	}
+	s.limit = adjustBalanceLimit(cluster, core.RegionKind)
+
+	op = schedule.CreateMovePeerOperator("balance-region", region, core.RegionKind, oldPeer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
+	return op, newPeer.GetStoreId(), true
+}
+
+// selectRegionToMove ranks stores by descending composite load score
+// (see storeLoadStats.score), then defers to s.policy to choose which of
+// the highest-scoring store's regions should be moved off of it. Target
+// store selection happens later, in transferPeer: the replica checker
+// there picks the one legal placement (respecting label/distinct-score
+// constraints we don't re-derive here), and shouldBalanceDimensions
+// rejects it unless its composite score is lower than source's — i.e.
+// target selection is gated on, rather than ranked by, ascending
+// composite score.
+func (s *balanceRegionScheduler) selectRegionToMove(cluster schedule.Cluster) (*core.RegionInfo, *metapb.Peer) {
+	stats := newStoreLoadStats(cluster.GetStores())
+	weights := s.opt.GetBalanceRegionWeights()
+	source := pickTentativeSource(cluster.GetStores(), stats, weights, s.filters, nil, nil)
+	if source == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no_store").Inc()
+		return nil, nil
+	}
+
+	regions := cluster.GetStoreRegions(source.GetId())
+	region := s.policy.Pick(cluster, source.GetId(), regions)
+	if region == nil {
+		schedulerCounter.WithLabelValues(s.GetName(), "no_region").Inc()
+		return nil, nil
+	}
+	return region, region.GetStorePeer(source.GetId())
+}
+
 func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *core.RegionInfo, oldPeer *metapb.Peer) *schedule.Operator {
 	// scoreGuard guarantees that the distinct score will not decrease.
 	stores := cluster.GetRegionStores(region)
@@ -116,7 +408,9 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	}
 
 	target := cluster.GetStore(newPeer.GetStoreId())
-	if !shouldBalance(source, target, core.RegionKind) {
+	stats := newStoreLoadStats(cluster.GetStores())
+	weights := s.opt.GetBalanceRegionWeights()
+	if !s.shouldBalanceDimensions(stats, source, target, weights, 0, 0) {
 		schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
 		return nil
 	}
@@ -125,6 +419,51 @@ func (s *balanceRegionScheduler) transferPeer(cluster schedule.Cluster, region *
 	return schedule.CreateMovePeerOperator("balance-region", region, core.RegionKind, oldPeer.GetStoreId(), newPeer.GetStoreId(), newPeer.GetId())
 }
 
+// shouldBalanceDimensions decides whether moving a region from source to
+// target is worthwhile. Unlike the region-count-only shouldBalance, it
+// requires that the move doesn't push any single dimension of target
+// (storage usage, write/read rate, pending peer count, region count)
+// beyond both what source already tolerated and the cluster average,
+// each plus a small cluster-relative tolerance (see dimensionRegressed),
+// even though the composite score overall is improving.
+//
+// sourceRegionCountDelta and targetRegionCountDelta let a caller that is
+// building up a batch of moves (see ScheduleBatch) check against the
+// region counts the two stores would have after the moves already
+// chosen earlier in the batch, rather than their currently-observed
+// counts. Pass 0, 0 outside of batch building.
+func (s *balanceRegionScheduler) shouldBalanceDimensions(stats *storeLoadStats, source, target *core.StoreInfo, weights *schedule.BalanceRegionWeights, sourceRegionCountDelta, targetRegionCountDelta int64) bool {
+	sourceScore := stats.scoreWithDelta(source, weights, sourceRegionCountDelta)
+	targetScore := stats.scoreWithDelta(target, weights, targetRegionCountDelta)
+	if targetScore >= sourceScore {
+		schedulerCounter.WithLabelValues(s.GetName(), "skip_composite_score").Inc()
+		return false
+	}
+
+	type dimension struct {
+		name      string
+		sourceVal float64
+		targetVal float64
+		avg       float64
+	}
+	writeBytes, readBytes := target.GetBytesWriteRate(), target.GetBytesReadRate()
+	sourceWriteBytes, sourceReadBytes := source.GetBytesWriteRate(), source.GetBytesReadRate()
+	dimensions := []dimension{
+		{"region_count", float64(source.RegionCount()) + float64(sourceRegionCountDelta), float64(target.RegionCount()) + float64(targetRegionCountDelta), stats.avgRegionCount},
+		{"storage", source.UsedRatio(), target.UsedRatio(), stats.avgUsedRatio},
+		{"write_bytes", sourceWriteBytes, writeBytes, stats.avgWriteRate},
+		{"read_bytes", sourceReadBytes, readBytes, stats.avgReadRate},
+		{"pending_peer", float64(source.PendingPeerCount()), float64(target.PendingPeerCount()), stats.avgPendingPeer},
+	}
+	for _, d := range dimensions {
+		if dimensionRegressed(d.sourceVal, d.targetVal, d.avg, weights.ToleranceRatio) {
+			schedulerCounter.WithLabelValues(s.GetName(), "skip_dimension_"+d.name).Inc()
+			return false
+		}
+	}
+	return true
+}
+
 // GetCache returns interval id cache in the scheduler. This is for test only.
 // TODO: remove it after moving tests into this directory.
 func (s *balanceRegionScheduler) GetCache() *cache.TTLUint64 {
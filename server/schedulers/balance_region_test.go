@@ -0,0 +1,118 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pingcap/pd/server/schedule"
+)
+
+func TestDimensionRegressedWithinTolerance(t *testing.T) {
+	// avg=100, tolerance ratio 0.1 -> 10 of slack. A target at 108 is
+	// worse than a source at 100 but still within tolerance of both the
+	// source and the cluster average, so it should not count as regressed.
+	if dimensionRegressed(100, 108, 100, 0.1) {
+		t.Fatalf("expected a move within tolerance to not be flagged as regressed")
+	}
+}
+
+func TestDimensionRegressedBeyondTolerance(t *testing.T) {
+	// Target exceeds both source+tolerance and avg+tolerance: a genuine
+	// regression that shouldBalanceDimensions must reject.
+	if !dimensionRegressed(100, 130, 100, 0.1) {
+		t.Fatalf("expected a move beyond tolerance to be flagged as regressed")
+	}
+}
+
+func TestDimensionRegressedBelowAverageNeverRegresses(t *testing.T) {
+	// A target dimension that's still below the cluster average is never
+	// a regression, even if it is worse than an unusually light source.
+	if dimensionRegressed(10, 90, 100, 0.1) {
+		t.Fatalf("a target still below the cluster average should not be flagged as regressed")
+	}
+}
+
+func TestDimensionRegressedZeroAverage(t *testing.T) {
+	// Small/empty clusters can have an all-zero dimension (e.g. no store
+	// has pending peers yet); this must not divide by zero or panic, and
+	// any nonzero target is trivially a regression against a zero source.
+	if !dimensionRegressed(0, 1, 0, 0.1) {
+		t.Fatalf("expected a nonzero target against an all-zero dimension to be flagged as regressed")
+	}
+	if dimensionRegressed(0, 0, 0, 0.1) {
+		t.Fatalf("two stores both at zero should never be flagged as regressed")
+	}
+}
+
+func TestCompositeScoreWeightsEachDimension(t *testing.T) {
+	weights := &schedule.BalanceRegionWeights{
+		RegionCountWeight: 1,
+		StorageWeight:     2,
+		WriteBytesWeight:  0,
+		ReadBytesWeight:   0,
+		PendingPeerWeight: 0,
+	}
+	got := compositeScore(weights, 1, 1, 100, 100, 100)
+	want := 1*1 + 2*1 + 0*100 + 0*100 + 0*100
+	if got != float64(want) {
+		t.Fatalf("expected zero-weighted dimensions to be ignored, got %v want %v", got, want)
+	}
+}
+
+func TestRegionPickPolicyNameFromArgs(t *testing.T) {
+	if got := regionPickPolicyNameFromArgs(nil); got != "" {
+		t.Fatalf("expected no args to yield the empty (default) policy name, got %q", got)
+	}
+	if got := regionPickPolicyNameFromArgs([]string{"coldest", "ignored-extra-arg"}); got != "coldest" {
+		t.Fatalf("expected the first arg to be used as the policy name, got %q", got)
+	}
+}
+
+func TestBalanceRegionSchedulerWiresPolicyFromFactoryArgs(t *testing.T) {
+	// Go through the registered "balance-region" factory itself (as the
+	// real scheduler-add command path does), not a hand-rolled
+	// re-invocation of its body, so this catches a bug in the
+	// RegisterScheduler wiring (e.g. a wrong arg index) rather than just
+	// re-confirming NewRegionPickPolicy/newBalanceRegionScheduler agree.
+	for _, name := range []string{"", "largest", "smallest", "coldest", "random"} {
+		sched, err := schedule.CreateScheduler("balance-region", nil, nil, []string{name})
+		if err != nil {
+			t.Fatalf("policy %q: unexpected error creating the registered scheduler: %v", name, err)
+		}
+		s, ok := sched.(*balanceRegionScheduler)
+		if !ok {
+			t.Fatalf("policy %q: expected the registered factory to return a *balanceRegionScheduler, got %T", name, sched)
+		}
+		want, err := schedule.NewRegionPickPolicy(name)
+		if err != nil {
+			t.Fatalf("policy %q: unexpected error: %v", name, err)
+		}
+		// Compare dynamic types: the "random" policy is a fresh pointer on
+		// every NewRegionPickPolicy call, so value equality never holds.
+		if reflect.TypeOf(s.policy) != reflect.TypeOf(want) {
+			t.Fatalf("policy %q: expected the factory to resolve the arg to the matching RegionPickPolicy", name)
+		}
+	}
+}
+
+func TestCompositeScoreOrdersByRatio(t *testing.T) {
+	weights := &schedule.DefaultBalanceRegionWeights
+	lightlyLoaded := compositeScore(weights, 0.5, 0.5, 0.5, 0.5, 0.5)
+	heavilyLoaded := compositeScore(weights, 2, 2, 2, 2, 2)
+	if lightlyLoaded >= heavilyLoaded {
+		t.Fatalf("expected a store with lower per-dimension ratios to score lower: %v >= %v", lightlyLoaded, heavilyLoaded)
+	}
+}